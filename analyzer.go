@@ -0,0 +1,84 @@
+package bayesbow
+
+import "strings"
+
+// Analyzer : テキストを単語(トークン)のリストに変換するインタフェース。
+// Config.Analyzer に設定すると AddText/PredictText がこれを通してから単語帳に登録する。
+type Analyzer interface {
+	Analyze(text string) []string
+}
+
+// WhitespaceAnalyzer : 空白文字で分割するだけの最も単純な Analyzer。
+type WhitespaceAnalyzer struct{}
+
+// Analyze : 空白区切りでトークン化する
+func (WhitespaceAnalyzer) Analyze(text string) []string {
+	return strings.Fields(text)
+}
+
+// LowercaseAnalyzer : Base の結果を小文字化するラッパー Analyzer。
+type LowercaseAnalyzer struct {
+	Base Analyzer
+}
+
+// Analyze : Base でトークン化したのち小文字化する
+func (a LowercaseAnalyzer) Analyze(text string) []string {
+	words := a.Base.Analyze(text)
+	r := make([]string, len(words))
+	for i, word := range words {
+		r[i] = strings.ToLower(word)
+	}
+	return r
+}
+
+// StemAnalyzer : Base の結果を英語の語幹 (PorterStem) に変換するラッパー Analyzer。
+type StemAnalyzer struct {
+	Base Analyzer
+}
+
+// Analyze : Base でトークン化したのち Porter の語幹抽出アルゴリズムで語幹化する
+func (a StemAnalyzer) Analyze(text string) []string {
+	words := a.Base.Analyze(text)
+	r := make([]string, len(words))
+	for i, word := range words {
+		r[i] = PorterStem(word)
+	}
+	return r
+}
+
+// NGramAnalyzer : Base の結果を N 個ずつ連結した n-gram に変換するラッパー Analyzer。
+// 生成される n-gram は Sep で連結した 1 つの文字列なので、既存の idxs マップへそのまま登録できる。
+type NGramAnalyzer struct {
+	Base Analyzer
+	N    int    // n-gram の n。1未満の場合は 1 として扱う
+	Sep  string // トークンの連結に使う区切り文字列。空文字の場合は "_" を使う
+}
+
+// Analyze : Base でトークン化したのち n-gram に変換する
+func (a NGramAnalyzer) Analyze(text string) []string {
+	words := a.Base.Analyze(text)
+	n := a.N
+	if n < 1 {
+		n = 1
+	}
+	sep := a.Sep
+	if sep == "" {
+		sep = "_"
+	}
+	if len(words) < n {
+		return nil
+	}
+	r := make([]string, 0, len(words)-n+1)
+	for i := 0; i+n <= len(words); i++ {
+		r = append(r, strings.Join(words[i:i+n], sep))
+	}
+	return r
+}
+
+// analyze : conf.Analyzer が設定されていればそれで、なければ WhitespaceAnalyzer でテキストをトークン化する
+func analyze(text string) []string {
+	if conf.Analyzer == nil {
+		return WhitespaceAnalyzer{}.Analyze(text)
+	}
+	return conf.Analyzer.Analyze(text)
+}
@@ -0,0 +1,234 @@
+package bayesbow
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Document : AddBatch に渡す1文書分のデータ
+type Document struct {
+	ID     string
+	Words  []string
+	Labels []int
+}
+
+// Progress : AddBatch の進捗通知
+type Progress struct {
+	Done  int // 処理済み文書数
+	Total int // 文書の総数
+}
+
+// batchShardResult : AddBatch のワーカー1つが自分のシャード分だけを集計した結果。
+// 単語IDはこのシャード内だけで閉じたローカルな番号であり、マージ時に dd.idxs のグローバルな番号へ付け替える。
+// LWFW/LabelWordWeightSum はここでは集計しない。idf は直前までの文書の処理順に依存するため
+// シャードに分けて並行計算することができず、マージ後に applyWeightedPass で別途計算する。
+type batchShardResult struct {
+	words          []string            // ローカル単語ID → 単語文字列
+	wordDocCount   []int               // ローカル単語ID → 出現する文書数
+	lwf            map[int]map[int]int // ラベルID → ローカル単語ID → 出現数
+	labelWordCount map[int]int         // ラベルID → 単語数
+	labelDocCount  []int               // ラベルID → 文書数
+	docs           [][]int             // このシャードの文書ごとのローカル単語IDの並び（文書の並び順を保つ）
+}
+
+func newBatchShardResult(labelCount int) *batchShardResult {
+	lwf := map[int]map[int]int{}
+	for labelID := 0; labelID < labelCount; labelID++ {
+		lwf[labelID] = map[int]int{}
+	}
+	return &batchShardResult{
+		lwf:            lwf,
+		labelWordCount: map[int]int{},
+		labelDocCount:  make([]int, labelCount),
+	}
+}
+
+// AddBatch : docs を workers 個のゴルーチンに分担させて並行に取り込む。
+// 各ワーカーは自分のシャードだけを使ってローカルな語彙・カウントを集計し、
+// 全ワーカー終了後にグローバルな idxs/LWF/LabelWordCount/WordDocCount/LabelDocCount/Docs へマージする。
+// conf.Weighting が従来以外のモードのときは、マージ後に applyWeightedPass が docs を元の順序で
+// 単一ゴルーチンでなぞり直し、LWFW/LabelWordWeightSum を Add を逐次呼んだ場合とビット同一になるよう計算する
+// （idf は文書が処理された時点までの WordDocCount/DocCount に依存するため、この部分だけは並行化できない）。
+// 以上により、docs を同じ順序で Add した場合と（単語IDの割り当て以外は）ビット同一の結果になる。
+// progress が nil でなければ、処理済み文書数の通知を逐次送信する。
+func (dd *Bow) AddBatch(docs []Document, workers int, progress chan<- Progress) {
+	if len(docs) == 0 {
+		return
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	weighted := conf.Weighting != WeightingCount
+	var preWordDocCount map[int]int
+	var preDocCount int
+	if weighted {
+		preDocCount = dd.DocCount
+		preWordDocCount = make(map[int]int, len(dd.WordDocCount))
+		for wordID, count := range dd.WordDocCount {
+			preWordDocCount[wordID] = count
+		}
+	}
+
+	shardSize := (len(docs) + workers - 1) / workers
+	shardResults := make([]*batchShardResult, workers)
+
+	var wg sync.WaitGroup
+	var done int32
+	for w := 0; w < workers; w++ {
+		start := w * shardSize
+		if start >= len(docs) {
+			shardResults[w] = newBatchShardResult(dd.LabelCount)
+			continue
+		}
+		end := start + shardSize
+		if end > len(docs) {
+			end = len(docs)
+		}
+
+		wg.Add(1)
+		go func(w, start, end int) {
+			defer wg.Done()
+			shardResults[w] = dd.addBatchShard(docs[start:end], progress, &done, len(docs))
+		}(w, start, end)
+	}
+	wg.Wait()
+
+	// マージはシャード番号順（= 元の docs の並び順）に単一ゴルーチンで行う
+	dd.mergeBatchShards(shardResults)
+
+	if weighted {
+		dd.applyWeightedPass(docs, preWordDocCount, preDocCount)
+	}
+}
+
+// addBatchShard : 1シャード分の文書を、Add と同じロジックでローカルな語彙・カウント表に集計する
+// (LWFW/LabelWordWeightSum は applyWeightedPass が別途計算するため、ここでは扱わない)
+func (dd *Bow) addBatchShard(docs []Document, progress chan<- Progress, done *int32, total int) *batchShardResult {
+	shard := newBatchShardResult(dd.LabelCount)
+	localIdxs := map[string]int{}
+
+	for _, doc := range docs {
+		seq := []int{}
+		freqWord := map[int]int{}
+
+		for _, word := range doc.Words {
+			// ストップワードは除外する
+			if word == "" || conf.UseStopWords && IsStopWord(word) {
+				continue
+			}
+
+			localID, ok := localIdxs[word]
+			if !ok {
+				localID = len(shard.words)
+				localIdxs[word] = localID
+				shard.words = append(shard.words, word)
+				shard.wordDocCount = append(shard.wordDocCount, 0)
+			}
+
+			seq = append(seq, localID)
+			freqWord[localID] = freqWord[localID] + 1
+		}
+
+		shard.docs = append(shard.docs, seq)
+
+		for localID := range freqWord {
+			for _, labelID := range doc.Labels {
+				shard.wordDocCount[localID]++
+				shard.lwf[labelID][localID] = shard.lwf[labelID][localID] + freqWord[localID]
+				shard.labelWordCount[labelID] = shard.labelWordCount[labelID] + freqWord[localID]
+			}
+		}
+
+		for _, labelID := range doc.Labels {
+			shard.labelDocCount[labelID]++
+		}
+
+		if progress != nil {
+			n := atomic.AddInt32(done, 1)
+			progress <- Progress{Done: int(n), Total: total}
+		}
+	}
+	return shard
+}
+
+// mergeBatchShards : 各シャードのローカルな集計結果を、dd のグローバルな状態へ付け替えながらマージする
+func (dd *Bow) mergeBatchShards(shards []*batchShardResult) {
+	for _, shard := range shards {
+		if shard == nil {
+			continue
+		}
+
+		// ローカル単語ID → グローバル単語ID のマッピングを作りつつ、新出の単語を dd.idxs に登録する
+		localToGlobal := make([]int, len(shard.words))
+		for localID, word := range shard.words {
+			globalID, ok := dd.idxs[word]
+			if !ok {
+				globalID = dd.WordCount
+				dd.idxs[word] = globalID
+				dd.WordCount++
+			}
+			localToGlobal[localID] = globalID
+		}
+
+		for localID, count := range shard.wordDocCount {
+			globalID := localToGlobal[localID]
+			dd.WordDocCount[globalID] = dd.WordDocCount[globalID] + count
+		}
+
+		for labelID, wordMap := range shard.lwf {
+			for localID, count := range wordMap {
+				globalID := localToGlobal[localID]
+				dd.LWF[labelID][globalID] = dd.LWF[labelID][globalID] + count
+			}
+		}
+
+		for labelID, count := range shard.labelWordCount {
+			dd.LabelWordCount[labelID] = dd.LabelWordCount[labelID] + count
+		}
+
+		for labelID, count := range shard.labelDocCount {
+			dd.LabelDocCount[labelID] = dd.LabelDocCount[labelID] + count
+		}
+
+		for _, localSeq := range shard.docs {
+			globalSeq := make([]int, len(localSeq))
+			for i, localID := range localSeq {
+				globalSeq[i] = localToGlobal[localID]
+			}
+			dd.Docs = append(dd.Docs, globalSeq)
+			dd.DocCount++
+		}
+	}
+}
+
+// applyWeightedPass : conf.Weighting が従来以外のモードのとき、マージ後に確定した dd.Docs の
+// 末尾（今回 AddBatch で追加された文書群、グローバル単語IDの並び）を元の docs の順序でなぞり直し、
+// Add と全く同じ進行順（文書ごとに DocCount を進め、単語ID→ラベルの順に WordDocCount を進めてから
+// idf を求める）で WordDocCount/DocCount を再現しながら LWFW/LabelWordWeightSum を計算する。
+// wordDocCount/docCount には AddBatch 開始時点（マージ前）の dd のスナップショットを渡し、
+// そこからローカルに増分することで、このバッチ呼び出し単独分の Add 逐次呼び出しと同じ値になる。
+func (dd *Bow) applyWeightedPass(docs []Document, wordDocCount map[int]int, docCount int) {
+	newDocs := dd.Docs[len(dd.Docs)-len(docs):]
+
+	for i, doc := range docs {
+		seq := newDocs[i]
+		freqWord := map[int]int{}
+		for _, wordID := range seq {
+			freqWord[wordID] = freqWord[wordID] + 1
+		}
+
+		docCount++
+
+		for wordID := range freqWord {
+			for _, labelID := range doc.Labels {
+				wordDocCount[wordID] = wordDocCount[wordID] + 1
+				idf := math.Log(float64(docCount+1) / float64(wordDocCount[wordID]+1))
+				w := dd.weightFor(freqWord[wordID], idf)
+				dd.LWFW[labelID][wordID] = dd.LWFW[labelID][wordID] + w
+				dd.LabelWordWeightSum[labelID] = dd.LabelWordWeightSum[labelID] + w
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package bayesbow
+
+import "testing"
+
+func testDocs() []Document {
+	docs := []Document{}
+	for i := 0; i < 37; i++ {
+		words := []string{"foo", "bar", "baz"}
+		if i%3 == 0 {
+			words = append(words, "qux")
+		}
+		docs = append(docs, Document{
+			ID:     string(rune('a' + i%26)),
+			Words:  words,
+			Labels: []int{i % 2},
+		})
+	}
+	return docs
+}
+
+// AddBatch は、単語IDの割り当てを除けば Add を逐次呼び出した場合とビット同一の結果になること。
+func TestAddBatchMatchesSerialAdd(t *testing.T) {
+	docs := testDocs()
+
+	serial := New("serial", []string{"even", "odd"})
+	for _, doc := range docs {
+		serial.Add(doc.ID, doc.Words, doc.Labels)
+	}
+
+	batch := New("batch", []string{"even", "odd"})
+	batch.AddBatch(docs, 4, nil)
+
+	if serial.WordCount != batch.WordCount {
+		t.Fatalf("WordCount mismatch: serial=%d batch=%d", serial.WordCount, batch.WordCount)
+	}
+	if serial.DocCount != batch.DocCount {
+		t.Fatalf("DocCount mismatch: serial=%d batch=%d", serial.DocCount, batch.DocCount)
+	}
+
+	for _, word := range serial.Words {
+		serialID := serial.idxs[word]
+		batchID, ok := batch.idxs[word]
+		if !ok {
+			t.Fatalf("word %q missing from batch vocabulary", word)
+		}
+		if serial.WordDocCount[serialID] != batch.WordDocCount[batchID] {
+			t.Errorf("WordDocCount[%q] mismatch: serial=%d batch=%d", word, serial.WordDocCount[serialID], batch.WordDocCount[batchID])
+		}
+		for labelID := 0; labelID < serial.LabelCount; labelID++ {
+			if serial.LWF[labelID][serialID] != batch.LWF[labelID][batchID] {
+				t.Errorf("LWF[%d][%q] mismatch: serial=%d batch=%d", labelID, word, serial.LWF[labelID][serialID], batch.LWF[labelID][batchID])
+			}
+		}
+	}
+	for labelID := 0; labelID < serial.LabelCount; labelID++ {
+		if serial.LabelWordCount[labelID] != batch.LabelWordCount[labelID] {
+			t.Errorf("LabelWordCount[%d] mismatch: serial=%d batch=%d", labelID, serial.LabelWordCount[labelID], batch.LabelWordCount[labelID])
+		}
+		if serial.LabelDocCount[labelID] != batch.LabelDocCount[labelID] {
+			t.Errorf("LabelDocCount[%d] mismatch: serial=%d batch=%d", labelID, serial.LabelDocCount[labelID], batch.LabelDocCount[labelID])
+		}
+	}
+}
+
+// Weighting が従来以外のモードのとき、AddBatch の LWFW/LabelWordWeightSum は
+// （単語IDの割り当てを除けば）Add を同じ順序で逐次呼び出した場合とビット同一になること。
+// workers=1 のケースも含めて確認し、並行処理特有のワーカー分割では出ない差分（idf のスナップショット
+// ずれ）も検出できるようにする。
+func TestAddBatchWeightedCountsMatchSerialAdd(t *testing.T) {
+	conf.Weighting = WeightingTFIDF
+	conf.Alpha = 1.0
+	defer func() { conf.Weighting = WeightingCount }()
+
+	for _, workers := range []int{1, 4} {
+		docs := testDocs()
+
+		serial := New("serial", []string{"even", "odd"})
+		for _, doc := range docs {
+			serial.Add(doc.ID, doc.Words, doc.Labels)
+		}
+
+		batch := New("batch", []string{"even", "odd"})
+		batch.AddBatch(docs, workers, nil)
+
+		for _, word := range serial.Words {
+			serialID := serial.idxs[word]
+			batchID, ok := batch.idxs[word]
+			if !ok {
+				t.Fatalf("workers=%d: word %q missing from batch vocabulary", workers, word)
+			}
+			for labelID := 0; labelID < serial.LabelCount; labelID++ {
+				want := serial.LWFW[labelID][serialID]
+				got := batch.LWFW[labelID][batchID]
+				if got != want {
+					t.Errorf("workers=%d: LWFW[%d][%q] mismatch: batch=%v serial=%v", workers, labelID, word, got, want)
+				}
+			}
+		}
+		for labelID := 0; labelID < serial.LabelCount; labelID++ {
+			if batch.LabelWordWeightSum[labelID] != serial.LabelWordWeightSum[labelID] {
+				t.Errorf("workers=%d: LabelWordWeightSum[%d] mismatch: batch=%v serial=%v", workers, labelID, batch.LabelWordWeightSum[labelID], serial.LabelWordWeightSum[labelID])
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package bayesbow
+
+import (
+	"math"
+	"sort"
+)
+
+// BM25 のデフォルトパラメータ (Robertson et al. で一般的に使われる値)
+const (
+	DefaultBM25K1 = 1.2
+	DefaultBM25B  = 0.75
+)
+
+// ScoreBM25 : 各ラベルを1つの「文書」とみなし、query に対する BM25 スコアを求める。
+// score(L,q) = Σ_{w∈q} IDF(w) * (f(w,L)*(k1+1)) / (f(w,L) + k1*(1-b+b*|L|/avgL))
+func (dd *Bow) ScoreBM25(query []string, k1, b float64) (r []float64) {
+	avgL := dd.avgLabelWordCount()
+	r = make([]float64, dd.LabelCount)
+
+	for _, word := range query {
+		wordID, ok := dd.idxs[word]
+		if !ok {
+			// 語彙にない単語は f(w,L)==0 相当なのでスコアに寄与しない
+			continue
+		}
+		idf := dd.bm25IDF(wordID)
+		for labelID := 0; labelID < dd.LabelCount; labelID++ {
+			r[labelID] += idf * dd.bm25TermWeight(labelID, wordID, avgL, k1, b)
+		}
+	}
+	return
+}
+
+// TopWordsForLabel : ラベル labelID の BM25 的な重みが高い順に上位 n 語を返す。
+// そのラベルの文書群に出現する単語のうち、IDF による希少性の重みづけが高い語を
+// クラスを特徴づける語として取り出す。
+func (dd *Bow) TopWordsForLabel(labelID, n int) (r []string) {
+	avgL := dd.avgLabelWordCount()
+	words := dd.wordsByID()
+
+	type wordWeight struct {
+		wordID int
+		weight float64
+	}
+	list := make([]wordWeight, 0, len(dd.LWF[labelID]))
+	for wordID := range dd.LWF[labelID] {
+		idf := dd.bm25IDF(wordID)
+		weight := idf * dd.bm25TermWeight(labelID, wordID, avgL, DefaultBM25K1, DefaultBM25B)
+		list = append(list, wordWeight{wordID, weight})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].weight > list[j].weight })
+
+	if n > len(list) {
+		n = len(list)
+	}
+	r = make([]string, n)
+	for i := 0; i < n; i++ {
+		r[i] = words[list[i].wordID]
+	}
+	return
+}
+
+// bm25TermWeight : (f(w,L)*(k1+1)) / (f(w,L) + k1*(1-b+b*|L|/avgL))
+func (dd *Bow) bm25TermWeight(labelID, wordID int, avgL, k1, b float64) float64 {
+	// avgL==0 はまだどのラベルにも単語が1件も追加されていない場合に起こりうる
+	// （Predict で語彙にだけ登録された未知語を検索した場合など）。このとき
+	// labelLen/avgL は 0/0 で NaN になってしまうため、情報なしとして0を返す。
+	if avgL == 0 {
+		return 0
+	}
+	f := float64(dd.LWF[labelID][wordID])
+	labelLen := float64(dd.LabelWordCount[labelID])
+	denom := f + k1*(1-b+b*labelLen/avgL)
+	if denom == 0 {
+		return 0
+	}
+	return (f * (k1 + 1)) / denom
+}
+
+// avgLabelWordCount : LabelWordCount の平均 (BM25 の avgL)
+func (dd *Bow) avgLabelWordCount() float64 {
+	if dd.LabelCount == 0 {
+		return 0
+	}
+	sum := 0
+	for labelID := 0; labelID < dd.LabelCount; labelID++ {
+		sum += dd.LabelWordCount[labelID]
+	}
+	return float64(sum) / float64(dd.LabelCount)
+}
+
+// bm25IDF : IDF(w) = log((DocCount - WordDocCount[w] + 0.5) / (WordDocCount[w] + 0.5) + 1)
+func (dd *Bow) bm25IDF(wordID int) float64 {
+	n := float64(dd.WordDocCount[wordID])
+	return math.Log((float64(dd.DocCount)-n+0.5)/(n+0.5) + 1)
+}
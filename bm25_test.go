@@ -0,0 +1,67 @@
+package bayesbow
+
+import "testing"
+
+func testBM25Bow() *Bow {
+	b := New("bm25", []string{"l0", "l1"})
+	b.Add("1", []string{"foo", "bar", "foo"}, []int{0})
+	b.Add("2", []string{"bar", "baz"}, []int{1})
+	b.Add("3", []string{"foo", "baz", "baz"}, []int{1})
+	return b
+}
+
+func TestScoreBM25(t *testing.T) {
+	b := testBM25Bow()
+
+	scores := b.ScoreBM25([]string{"foo"}, DefaultBM25K1, DefaultBM25B)
+	if len(scores) != 2 {
+		t.Fatalf("ScoreBM25 returned %d scores, want 2", len(scores))
+	}
+	if scores[0] <= 0 {
+		t.Errorf("label 0 contains foo twice, expected positive score, got %v", scores[0])
+	}
+
+	// 語彙にない単語は寄与せず、スコアが0のままであること
+	scores = b.ScoreBM25([]string{"qux"}, DefaultBM25K1, DefaultBM25B)
+	for labelID, s := range scores {
+		if s != 0 {
+			t.Errorf("label %d: OOV query should score 0, got %v", labelID, s)
+		}
+	}
+}
+
+func TestTopWordsForLabel(t *testing.T) {
+	b := testBM25Bow()
+
+	words := b.TopWordsForLabel(1, 2)
+	if len(words) != 2 {
+		t.Fatalf("TopWordsForLabel returned %d words, want 2", len(words))
+	}
+}
+
+// LabelCount==0 のとき avgLabelWordCount は 0 を返し、ScoreBM25 が
+// ゼロ除算 (0/0 の NaN 伝播) を起こさず空のスコア列を返すこと。
+func TestScoreBM25ZeroLabels(t *testing.T) {
+	b := New("empty", []string{})
+
+	scores := b.ScoreBM25([]string{"foo"}, DefaultBM25K1, DefaultBM25B)
+	if len(scores) != 0 {
+		t.Fatalf("ScoreBM25 returned %d scores, want 0", len(scores))
+	}
+}
+
+// LabelCount>0 でも、まだどのラベルにも単語が1件も Add されていない場合は LabelWordCount が
+// 全て0になり avgLabelWordCount も0になる（Predict は未知語を dd.idxs に登録するだけで
+// LWF/LabelWordCount には触れないため、この状態で語彙に単語だけ存在することがありうる）。
+// このとき bm25TermWeight が 0/0 を計算して NaN を返さず、0 を返すこと。
+func TestScoreBM25ZeroAvgLabelWordCount(t *testing.T) {
+	b := New("empty-labels", []string{"l0", "l1"})
+	b.Predict([]string{"foo"}) // LWF/LabelWordCount を更新せずに "foo" を語彙へ登録するだけ
+
+	scores := b.ScoreBM25([]string{"foo"}, DefaultBM25K1, DefaultBM25B)
+	for labelID, s := range scores {
+		if s != 0 {
+			t.Errorf("label %d: expected 0 when avgLabelWordCount is 0, got %v", labelID, s)
+		}
+	}
+}
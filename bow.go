@@ -1,283 +1,428 @@
-// Package bayesbow --- Bayes Bag of Words ベイズ推定を用いて文書の分類を行うパッケージ。
-package bayesbow
-
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"math"
-	"os"
-	"strings"
-)
-
-// Bow : Bag of Words --- 文書群を単語の集合で表現する型。文書群データの型。
-type Bow struct {
-	Note           string              `json:"note"`           // ノート
-	LabelNames     []string            `json:"labelnames"`     // ラベル名のリスト (ラベルID → ラベル名)
-	LabelCount     int                 `json:"labelcount"`     // ラベルの個数。LabelCount==len(LabelNames)
-	Words          []string            `json:"words"`          // 単語帳 (単語ID → 単語文字列)
-	WordCount      int                 `json:"wordcount"`      // 単語数。WordCount==len(Words)
-	WordDocCount   map[int]int         `json:"worddoccount"`   // 単語の出現する文書数 (単語ID → 文書数)
-	DocCount       int                 `json:"doccount"`       // 文書数
-	LWF            map[int]map[int]int `json:"lwf"`            // ラベルごとの単語の出現数 (ラベルID → 単語ID → 出現数)
-	LabelWordCount map[int]int         `json:"labelwordcount"` // ラベルごとの単語数 (ラベルID → 単語数)
-	PL             []float64           `json:"pl"`             // ラベルごとの確率。PL : Property of Label
-	LabelDocCount  []int               `json:"labeldoccount"`  // ラベルごとの文書数 (ラベルID → 文書数)
-	idxs           map[string]int      // 単語帳(Words集計用) (単語文字列 → 単語ID)
-}
-
-// 例 ラベルID labelID に属する文書群に出現する単語 "foo" の出現数を調べる。
-//      wordID := dd.idxs["foo"]
-//      numWord := dd.LWF[labelID][wordID]
-
-// New : 文書群データの作成
-func New(note string, labelNames []string) (r *Bow) {
-	labelCount := len(labelNames)
-	pl := make([]float64, labelCount)
-	lwf := map[int]map[int]int{}
-	for labelID := 0; labelID < labelCount; labelID++ {
-		pl[labelID] = float64(1.0) / float64(labelCount)
-		lwf[labelID] = map[int]int{}
-	}
-
-	r = &Bow{
-		Note:           note,
-		LWF:            lwf,
-		PL:             pl,
-		idxs:           map[string]int{},
-		WordCount:      0,
-		WordDocCount:   map[int]int{},
-		LabelWordCount: map[int]int{},
-		LabelNames:     labelNames,
-		LabelCount:     labelCount,
-		LabelDocCount:  make([]int, labelCount),
-	}
-	return
-}
-
-// Predict : 文書のラベルを推定する
-// r --- 推定したラベルID
-// pld --- 各ラベルIDごとの確率
-func (dd *Bow) Predict(words []string) (r int, pld []float64) {
-	// 文書内の単語の出現回数 (単語ID→単語の出現回数)
-	freqWord := map[int]int{}
-	for _, word := range words {
-
-		// ストップワードは除外する
-		if word == "" || conf.UseStopWords && IsStopWord(word) {
-			continue
-		}
-
-		// 新しい単語かどうかを dd.idxs に登録されているかでチェック
-		_, ok := dd.idxs[word]
-		if !ok {
-			// dd.idxs に登録されていなければ最新の単語追加し、dd.WordCountをインクリメント
-			dd.idxs[word] = dd.WordCount
-			dd.WordCount++
-		}
-
-		// 単語ID の取得
-		wordID := dd.idxs[word]
-
-		// 文書内の単語出現回数
-		freqWord[wordID] = freqWord[wordID] + 1
-
-	}
-
-	// ある文書を前提とした各ラベルの確率。（イメージとしてある文書が各ラベルにどれだけ重なりがあるかを単語の出現回数を元に求める）
-	pld = dd.PLD(freqWord)
-	maxLabelID := 0
-	for labelID := 0; labelID < dd.LabelCount; labelID++ {
-		//dd.PL[labelID] = pld[labelID] // ←ベイズ更新？！
-		if pld[labelID] > pld[maxLabelID] {
-			maxLabelID = labelID
-		}
-	}
-	r = maxLabelID
-	return
-}
-
-// Add : 文書を追加する
-func (dd *Bow) Add(id string, words []string, labels []int) {
-
-	// サンプル
-	// dd.Add("文書001", []string{"これ", "は", "ペン", "です"}, []int{34})
-
-	// 単語リスト（単語IDのリスト）
-	seq := []int{}
-	// 文書内の単語の出現回数 (単語ID→単語の出現回数)
-	freqWord := map[int]int{}
-
-	for _, word := range words {
-
-		// ストップワードは除外する
-		if word == "" || conf.UseStopWords && IsStopWord(word) {
-			continue
-		}
-
-		// idx --- 単語ID 各単語の全文書横断で一意な番号
-		_, ok := dd.idxs[word]
-		if !ok {
-			// dd.idxs に登録されていなければ最新の単語追加し、dd.WordCountをインクリメント
-			dd.idxs[word] = dd.WordCount
-			dd.WordCount++
-		}
-
-		// 単語ID の取得
-		idx := dd.idxs[word]
-		// 単語リストに追加
-		seq = append(seq, idx)
-
-		// 文書内の単語出現回数
-		freqWord[idx] = freqWord[idx] + 1
-
-	}
-
-	// 文書の総数をインクリメント
-	dd.DocCount++
-
-	// 出現した各単語IDについて、WordDocCount,LWF,LabelWordCount を更新する。
-	// LWF (each Labels's Word Frequency) : ラベルごとの各単語の出現数
-	for wordID := range freqWord {
-		// [MEMO] freqWord は map なので、freqWord から range で引っ張っている wordID は
-		// 重複がないので、以下の処理では wordID の重複を意識しないで済んでいることに注意。
-
-		// 各ラベルごとの単語の出現数をインクリメントする
-		for _, labelID := range labels {
-
-			// 単語の出現する文書数をカウントアップする
-			dd.WordDocCount[wordID] = dd.WordDocCount[wordID] + 1
-
-			// ラベルごと単語ごとの出現数をカウントアップする
-			dd.LWF[labelID][wordID] = dd.LWF[labelID][wordID] + freqWord[wordID]
-
-			// ラベルごとの単語数をカウントアップする
-			dd.LabelWordCount[labelID] = dd.LabelWordCount[labelID] + freqWord[wordID]
-		}
-	}
-
-	for _, labelID := range labels {
-		// 該当するラベルの文書数をカウントアップする
-		dd.LabelDocCount[labelID] = dd.LabelDocCount[labelID] + 1
-	}
-	return
-}
-
-// WordDocCountOf : 与えられた単語の出現文書数
-func (dd *Bow) WordDocCountOf(word string) int {
-	idx, ok := dd.idxs[word]
-	if !ok {
-		return 0
-	}
-	return dd.WordDocCount[idx]
-}
-
-// UpdatePL : PL の更新
-func (dd *Bow) UpdatePL() {
-	for labelID := 0; labelID < dd.LabelCount; labelID++ {
-		if dd.LabelDocCount[labelID]+1 > dd.DocCount+dd.LabelCount {
-			fmt.Println("dd.LabelDocCount[", labelID, "] =", dd.LabelDocCount[labelID])
-			fmt.Println("dd.DocCount =", dd.DocCount)
-			fmt.Println("dd.LabelCount =", dd.LabelCount)
-			panic("dd.LabelDocCount[labelID]+1 > dd.DocCount+dd.LabelCount !!")
-		}
-		dd.PL[labelID] = float64(dd.LabelDocCount[labelID]+1) / float64(dd.DocCount+dd.LabelCount)
-	}
-}
-
-// PWL : Property of Word in Label あるラベルにおける所定の単語の出現率（log版）
-func (dd *Bow) PWL(labelID, wordID int) (r float64) {
-
-	r = math.Log(float64(dd.LWF[labelID][wordID]+1) / float64(dd.LabelWordCount[labelID]+dd.WordCount))
-	return
-}
-
-// PLD : Property of Label over Document ある文書を前提としたラベルの確率（log版）
-// イメージとしてある文書が各ラベルにどれだけ重なりがあるかを単語の出現回数を元に求める
-func (dd *Bow) PLD(wordFreq map[int]int) (r []float64) {
-	dd.UpdatePL()
-	r = make([]float64, dd.LabelCount)
-	sum := float64(0.0)
-	for labelID := 0; labelID < dd.LabelCount; labelID++ {
-		r[labelID] = math.Log(dd.PL[labelID])
-		for wordID := range wordFreq {
-			r[labelID] += dd.PWL(labelID, wordID)
-		}
-		r[labelID] = math.Exp(r[labelID])
-		sum += r[labelID]
-	}
-	for labelID := 0; labelID < dd.LabelCount; labelID++ {
-		r[labelID] /= sum
-	}
-	return
-}
-
-func (dd *Bow) updateWords() {
-	dd.Words = make([]string, len(dd.idxs))
-	for word, idx := range dd.idxs {
-		dd.Words[idx] = word
-	}
-}
-
-// Load : 文書データの読み出し
-func Load(inFile string) (dd *Bow, err error) {
-	var bytes []byte
-	bytes, err = ioutil.ReadFile(inFile)
-	if err != nil {
-		return
-	}
-	var d Bow
-	err = json.Unmarshal(bytes, &d)
-	if err != nil {
-		return
-	}
-
-	// d.idxs : 単語帳 (単語文字列 → 単語ID)
-	d.idxs = map[string]int{}
-	for idx, word := range d.Words {
-		d.idxs[word] = idx
-	}
-
-	dd = &d
-	return
-}
-
-// Save : 文書データの保存
-func (dd *Bow) Save(outFile string) (err error) {
-
-	// Words を更新
-	dd.updateWords()
-
-	var w *os.File
-	w, err = os.Create(outFile)
-	if err != nil {
-		return
-	}
-	defer w.Close()
-	var b []byte
-	b, err = json.Marshal(dd)
-
-	//_, err = w.Write(bytes)
-	var out bytes.Buffer
-	json.Indent(&out, b, "", "  ")
-	out.WriteTo(w)
-	return
-}
-
-// IsStopWord : ストップワードかどうか
-func IsStopWord(x string) (r bool) {
-	for _, word := range conf.StopWords {
-		if x == word {
-			r = true
-			return
-		}
-	}
-	for _, wordClass := range conf.StopWordClasses {
-		if strings.HasPrefix(x, wordClass) {
-			r = true
-			break
-		}
-	}
-	return
-}
+// Package bayesbow --- Bayes Bag of Words ベイズ推定を用いて文書の分類を行うパッケージ。
+package bayesbow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+)
+
+// Bow : Bag of Words --- 文書群を単語の集合で表現する型。文書群データの型。
+type Bow struct {
+	Note               string                  `json:"note"`               // ノート
+	LabelNames         []string                `json:"labelnames"`         // ラベル名のリスト (ラベルID → ラベル名)
+	LabelCount         int                     `json:"labelcount"`         // ラベルの個数。LabelCount==len(LabelNames)
+	Words              []string                `json:"words"`              // 単語帳 (単語ID → 単語文字列)
+	WordCount          int                     `json:"wordcount"`          // 単語数。WordCount==len(Words)
+	WordDocCount       map[int]int             `json:"worddoccount"`       // 単語の出現する文書数 (単語ID → 文書数)
+	DocCount           int                     `json:"doccount"`           // 文書数
+	LWF                map[int]map[int]int     `json:"lwf"`                // ラベルごとの単語の出現数 (ラベルID → 単語ID → 出現数)
+	LabelWordCount     map[int]int             `json:"labelwordcount"`     // ラベルごとの単語数 (ラベルID → 単語数)
+	PL                 []float64               `json:"pl"`                 // ラベルごとの確率。PL : Property of Label
+	LabelDocCount      []int                   `json:"labeldoccount"`      // ラベルごとの文書数 (ラベルID → 文書数)
+	LWFW               map[int]map[int]float64 `json:"lwfw"`               // ラベルごとの単語の重み付き出現数 (ラベルID → 単語ID → 重み) Weighting が従来以外のときに使う
+	LabelWordWeightSum map[int]float64         `json:"labelwordweightsum"` // ラベルごとの重みの合計 (ラベルID → 重み合計) Weighting が従来以外のときに使う
+	Docs               [][]int                 `json:"docs"`               // 文書ごとの単語IDの並び (docID → 単語IDの並び)。LDA など文書内の語順を必要とする機能のために保持する
+	idxs               map[string]int          // 単語帳(Words集計用) (単語文字列 → 単語ID)
+}
+
+// 例 ラベルID labelID に属する文書群に出現する単語 "foo" の出現数を調べる。
+//      wordID := dd.idxs["foo"]
+//      numWord := dd.LWF[labelID][wordID]
+
+// New : 文書群データの作成
+func New(note string, labelNames []string) (r *Bow) {
+	labelCount := len(labelNames)
+	pl := make([]float64, labelCount)
+	lwf := map[int]map[int]int{}
+	lwfw := map[int]map[int]float64{}
+	for labelID := 0; labelID < labelCount; labelID++ {
+		pl[labelID] = float64(1.0) / float64(labelCount)
+		lwf[labelID] = map[int]int{}
+		lwfw[labelID] = map[int]float64{}
+	}
+
+	r = &Bow{
+		Note:               note,
+		LWF:                lwf,
+		PL:                 pl,
+		idxs:               map[string]int{},
+		WordCount:          0,
+		WordDocCount:       map[int]int{},
+		LabelWordCount:     map[int]int{},
+		LabelNames:         labelNames,
+		LabelCount:         labelCount,
+		LabelDocCount:      make([]int, labelCount),
+		LWFW:               lwfw,
+		LabelWordWeightSum: map[int]float64{},
+	}
+	return
+}
+
+// Predict : 文書のラベルを推定する
+// r --- 推定したラベルID
+// pld --- 各ラベルIDごとの確率
+func (dd *Bow) Predict(words []string) (r int, pld []float64) {
+	// 文書内の単語の出現回数 (単語ID→単語の出現回数)
+	freqWord := map[int]int{}
+	for _, word := range words {
+
+		// ストップワードは除外する
+		if word == "" || conf.UseStopWords && IsStopWord(word) {
+			continue
+		}
+
+		// 新しい単語かどうかを dd.idxs に登録されているかでチェック
+		_, ok := dd.idxs[word]
+		if !ok {
+			// dd.idxs に登録されていなければ最新の単語追加し、dd.WordCountをインクリメント
+			dd.idxs[word] = dd.WordCount
+			dd.WordCount++
+		}
+
+		// 単語ID の取得
+		wordID := dd.idxs[word]
+
+		// 文書内の単語出現回数
+		freqWord[wordID] = freqWord[wordID] + 1
+
+	}
+
+	// ある文書を前提とした各ラベルの確率。（イメージとしてある文書が各ラベルにどれだけ重なりがあるかを単語の出現回数を元に求める）
+	pld = dd.PLD(freqWord)
+	maxLabelID := 0
+	for labelID := 0; labelID < dd.LabelCount; labelID++ {
+		//dd.PL[labelID] = pld[labelID] // ←ベイズ更新？！
+		if pld[labelID] > pld[maxLabelID] {
+			maxLabelID = labelID
+		}
+	}
+	r = maxLabelID
+	return
+}
+
+// Add : 文書を追加する
+func (dd *Bow) Add(id string, words []string, labels []int) {
+
+	// サンプル
+	// dd.Add("文書001", []string{"これ", "は", "ペン", "です"}, []int{34})
+
+	// 単語リスト（単語IDのリスト）
+	seq := []int{}
+	// 文書内の単語の出現回数 (単語ID→単語の出現回数)
+	freqWord := map[int]int{}
+
+	for _, word := range words {
+
+		// ストップワードは除外する
+		if word == "" || conf.UseStopWords && IsStopWord(word) {
+			continue
+		}
+
+		// idx --- 単語ID 各単語の全文書横断で一意な番号
+		_, ok := dd.idxs[word]
+		if !ok {
+			// dd.idxs に登録されていなければ最新の単語追加し、dd.WordCountをインクリメント
+			dd.idxs[word] = dd.WordCount
+			dd.WordCount++
+		}
+
+		// 単語ID の取得
+		idx := dd.idxs[word]
+		// 単語リストに追加
+		seq = append(seq, idx)
+
+		// 文書内の単語出現回数
+		freqWord[idx] = freqWord[idx] + 1
+
+	}
+
+	// 文書の総数をインクリメント
+	dd.DocCount++
+
+	// 文書内の単語IDの並びを保持する（LDA など語順を必要とする機能のため）
+	dd.Docs = append(dd.Docs, seq)
+
+	// 出現した各単語IDについて、WordDocCount,LWF,LabelWordCount を更新する。
+	// LWF (each Labels's Word Frequency) : ラベルごとの各単語の出現数
+	for wordID := range freqWord {
+		// [MEMO] freqWord は map なので、freqWord から range で引っ張っている wordID は
+		// 重複がないので、以下の処理では wordID の重複を意識しないで済んでいることに注意。
+
+		// 各ラベルごとの単語の出現数をインクリメントする
+		for _, labelID := range labels {
+
+			// 単語の出現する文書数をカウントアップする
+			dd.WordDocCount[wordID] = dd.WordDocCount[wordID] + 1
+
+			// ラベルごと単語ごとの出現数をカウントアップする
+			dd.LWF[labelID][wordID] = dd.LWF[labelID][wordID] + freqWord[wordID]
+
+			// ラベルごとの単語数をカウントアップする
+			dd.LabelWordCount[labelID] = dd.LabelWordCount[labelID] + freqWord[wordID]
+
+			// Weighting が従来以外のモードなら、重み付きのカウントも更新する
+			if conf.Weighting != WeightingCount {
+				w := dd.weightOf(wordID, freqWord[wordID])
+				dd.LWFW[labelID][wordID] = dd.LWFW[labelID][wordID] + w
+				dd.LabelWordWeightSum[labelID] = dd.LabelWordWeightSum[labelID] + w
+			}
+		}
+	}
+
+	for _, labelID := range labels {
+		// 該当するラベルの文書数をカウントアップする
+		dd.LabelDocCount[labelID] = dd.LabelDocCount[labelID] + 1
+	}
+	return
+}
+
+// PredictStrict : Predict と異なり、未知語を dd.idxs に追加せず読み飛ばす。
+// Load したモデルで推論する場合、Predict は未知語をその場で語彙に登録してしまうため、
+// 推論のたびに語彙が汚染される。こちらは idxs を変更せず OOV の単語を黙って無視する。
+func (dd *Bow) PredictStrict(words []string) (r int, pld []float64) {
+	// 文書内の単語の出現回数 (単語ID→単語の出現回数)
+	freqWord := map[int]int{}
+	for _, word := range words {
+
+		// ストップワードは除外する
+		if word == "" || conf.UseStopWords && IsStopWord(word) {
+			continue
+		}
+
+		// dd.idxs に登録されていない単語(OOV)は読み飛ばす
+		wordID, ok := dd.idxs[word]
+		if !ok {
+			continue
+		}
+
+		// 文書内の単語出現回数
+		freqWord[wordID] = freqWord[wordID] + 1
+
+	}
+
+	pld = dd.PLD(freqWord)
+	maxLabelID := 0
+	for labelID := 0; labelID < dd.LabelCount; labelID++ {
+		if pld[labelID] > pld[maxLabelID] {
+			maxLabelID = labelID
+		}
+	}
+	r = maxLabelID
+	return
+}
+
+// AddText : テキストを conf.Analyzer でトークン化してから追加する
+func (dd *Bow) AddText(id, text string, labels []int) {
+	dd.Add(id, analyze(text), labels)
+}
+
+// PredictText : テキストを conf.Analyzer でトークン化してからラベルを推定する
+func (dd *Bow) PredictText(text string) (r int, pld []float64) {
+	return dd.Predict(analyze(text))
+}
+
+// WordDocCountOf : 与えられた単語の出現文書数
+func (dd *Bow) WordDocCountOf(word string) int {
+	idx, ok := dd.idxs[word]
+	if !ok {
+		return 0
+	}
+	return dd.WordDocCount[idx]
+}
+
+// UpdatePL : PL の更新
+func (dd *Bow) UpdatePL() {
+	for labelID := 0; labelID < dd.LabelCount; labelID++ {
+		if dd.LabelDocCount[labelID]+1 > dd.DocCount+dd.LabelCount {
+			fmt.Println("dd.LabelDocCount[", labelID, "] =", dd.LabelDocCount[labelID])
+			fmt.Println("dd.DocCount =", dd.DocCount)
+			fmt.Println("dd.LabelCount =", dd.LabelCount)
+			panic("dd.LabelDocCount[labelID]+1 > dd.DocCount+dd.LabelCount !!")
+		}
+		dd.PL[labelID] = float64(dd.LabelDocCount[labelID]+1) / float64(dd.DocCount+dd.LabelCount)
+	}
+}
+
+// PWL : Property of Word in Label あるラベルにおける所定の単語の出現率（log版）
+// conf.Weighting が WeightingCount（デフォルト）のときは従来どおり出現回数ベースで、
+// それ以外のときは重み付きカウント(LWFW/LabelWordWeightSum)ベースで計算する。
+func (dd *Bow) PWL(labelID, wordID int) (r float64) {
+
+	if conf.Weighting == WeightingCount {
+		r = math.Log(float64(dd.LWF[labelID][wordID]+1) / float64(dd.LabelWordCount[labelID]+dd.WordCount))
+		return
+	}
+
+	alpha := conf.Alpha
+	if alpha == 0 {
+		alpha = 1.0
+	}
+	r = math.Log((dd.LWFW[labelID][wordID] + alpha) / (dd.LabelWordWeightSum[labelID] + alpha*float64(dd.WordCount)))
+	return
+}
+
+// PLD : Property of Label over Document ある文書を前提としたラベルの確率（log版）
+// イメージとしてある文書が各ラベルにどれだけ重なりがあるかを単語の出現回数を元に求める
+func (dd *Bow) PLD(wordFreq map[int]int) (r []float64) {
+	dd.UpdatePL()
+	r = make([]float64, dd.LabelCount)
+	weighted := conf.Weighting != WeightingCount
+	sum := float64(0.0)
+	for labelID := 0; labelID < dd.LabelCount; labelID++ {
+		r[labelID] = math.Log(dd.PL[labelID])
+		for wordID, tf := range wordFreq {
+			if weighted {
+				// 重み付けモードでは、単語の重みを掛けてから加算する（単純な重複なし和ではない）
+				r[labelID] += dd.weightOf(wordID, tf) * dd.PWL(labelID, wordID)
+			} else {
+				r[labelID] += dd.PWL(labelID, wordID)
+			}
+		}
+		r[labelID] = math.Exp(r[labelID])
+		sum += r[labelID]
+	}
+	for labelID := 0; labelID < dd.LabelCount; labelID++ {
+		r[labelID] /= sum
+	}
+	return
+}
+
+// weightOf : conf.Weighting に応じた単語の重みを返す (tf はその単語の文書内の出現回数)
+func (dd *Bow) weightOf(wordID, tf int) float64 {
+	return dd.weightFor(tf, dd.idfOf(wordID))
+}
+
+// weightFor : conf.Weighting と、あらかじめ求めた idf 値から単語の重みを返す。
+// AddBatch の applyWeightedPass は dd の現在の WordDocCount/DocCount ではなく、自前で再現した
+// 文書頻度から idf を求めるため idfOf(wordID) が使えず、その idf を直接渡せるようにこの関数を weightOf から切り出している。
+func (dd *Bow) weightFor(tf int, idf float64) (r float64) {
+	switch conf.Weighting {
+	case WeightingTF:
+		r = float64(tf)
+	case WeightingTFIDF:
+		r = float64(tf)*idf + 1
+	case WeightingSublinearTFIDF:
+		r = math.Log(float64(tf)+1)*idf + 1
+	default:
+		r = float64(tf)
+	}
+	return
+}
+
+// IDF : 単語の逆文書頻度 (IDF : Inverse Document Frequency) を返す。未登録語は文書頻度0として扱う。
+func (dd *Bow) IDF(word string) (r float64) {
+	wordDocCount := 0
+	if idx, ok := dd.idxs[word]; ok {
+		wordDocCount = dd.WordDocCount[idx]
+	}
+	r = math.Log(float64(dd.DocCount+1) / float64(wordDocCount+1))
+	return
+}
+
+// idfOf : 単語ID版の IDF。
+func (dd *Bow) idfOf(wordID int) float64 {
+	return math.Log(float64(dd.DocCount+1) / float64(dd.WordDocCount[wordID]+1))
+}
+
+// ensureWeightedMaps : LWFW/LabelWordWeightSum を持たない旧形式の保存データ（これらの
+// フィールドが追加される前に Save/SaveGob されたファイル）を読み込んだときに、
+// New と同じ形（ラベルごとの空マップ）で補って初期化する。これをしないと、Load/LoadGob の
+// 戻り値に対して Weighting が従来以外のモードで Add を呼んだ際に nil map への書き込みで panic する。
+func (dd *Bow) ensureWeightedMaps() {
+	if dd.LWFW == nil {
+		dd.LWFW = map[int]map[int]float64{}
+	}
+	for labelID := 0; labelID < dd.LabelCount; labelID++ {
+		if dd.LWFW[labelID] == nil {
+			dd.LWFW[labelID] = map[int]float64{}
+		}
+	}
+	if dd.LabelWordWeightSum == nil {
+		dd.LabelWordWeightSum = map[int]float64{}
+	}
+}
+
+// wordsByID : 単語ID順の単語一覧を dd.idxs から再構築して返す。
+// dd.Words は Add/Predict では更新されず Save/Load/Prune などのときにだけ作り直されるため、実行中の最新の語彙が必要な場合はこちらを使う。
+func (dd *Bow) wordsByID() []string {
+	words := make([]string, dd.WordCount)
+	for word, id := range dd.idxs {
+		words[id] = word
+	}
+	return words
+}
+
+func (dd *Bow) updateWords() {
+	dd.Words = make([]string, len(dd.idxs))
+	for word, idx := range dd.idxs {
+		dd.Words[idx] = word
+	}
+}
+
+// Load : 文書データの読み出し
+func Load(inFile string) (dd *Bow, err error) {
+	var bytes []byte
+	bytes, err = ioutil.ReadFile(inFile)
+	if err != nil {
+		return
+	}
+	var d Bow
+	err = json.Unmarshal(bytes, &d)
+	if err != nil {
+		return
+	}
+
+	// d.idxs : 単語帳 (単語文字列 → 単語ID)
+	d.idxs = map[string]int{}
+	for idx, word := range d.Words {
+		d.idxs[word] = idx
+	}
+
+	dd = &d
+	dd.ensureWeightedMaps()
+	return
+}
+
+// Save : 文書データの保存
+func (dd *Bow) Save(outFile string) (err error) {
+
+	// Words を更新
+	dd.updateWords()
+
+	var w *os.File
+	w, err = os.Create(outFile)
+	if err != nil {
+		return
+	}
+	defer w.Close()
+	var b []byte
+	b, err = json.Marshal(dd)
+
+	//_, err = w.Write(bytes)
+	var out bytes.Buffer
+	json.Indent(&out, b, "", "  ")
+	out.WriteTo(w)
+	return
+}
+
+// IsStopWord : ストップワードかどうか
+func IsStopWord(x string) (r bool) {
+	for _, word := range conf.StopWords {
+		if x == word {
+			r = true
+			return
+		}
+	}
+	for _, wordClass := range conf.StopWordClasses {
+		if strings.HasPrefix(x, wordClass) {
+			r = true
+			break
+		}
+	}
+	return
+}
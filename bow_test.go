@@ -0,0 +1,83 @@
+package bayesbow
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"testing"
+)
+
+// LWFW/LabelWordWeightSum が登場する前の古い形式の JSON（lwfw/labelwordweightsum キーを含まない）を
+// Load したあと、重み付けモードで Add しても nil map への書き込みで panic しないこと。
+func TestLoadOldFormatJSONThenWeightedAdd(t *testing.T) {
+	const oldFormatJSON = `{
+		"note": "old",
+		"labelnames": ["l0", "l1"],
+		"labelcount": 2,
+		"words": ["foo"],
+		"wordcount": 1,
+		"worddoccount": {"0": 1},
+		"doccount": 1,
+		"lwf": {"0": {"0": 1}, "1": {}},
+		"labelwordcount": {"0": 1, "1": 0},
+		"pl": [0.5, 0.5],
+		"labeldoccount": [1, 0]
+	}`
+
+	f, err := os.CreateTemp("", "bayesbow-old-*.json")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(oldFormatJSON); err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+	f.Close()
+
+	dd, err := Load(f.Name())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	conf.Weighting = WeightingTFIDF
+	defer func() { conf.Weighting = WeightingCount }()
+
+	dd.Add("new", []string{"bar"}, []int{1})
+
+	if dd.LWFW[1][dd.idxs["bar"]] <= 0 {
+		t.Errorf("LWFW should be populated after Add on a loaded old-format file, got %v", dd.LWFW[1][dd.idxs["bar"]])
+	}
+	if dd.LabelWordWeightSum[1] <= 0 {
+		t.Errorf("LabelWordWeightSum should be populated, got %v", dd.LabelWordWeightSum[1])
+	}
+}
+
+// LoadGob 版でも同様に、lwfw/labelwordweightsum を持たない旧形式のバイナリを読み込んだ
+// あとの重み付け Add で panic しないこと。
+func TestLoadGobOldFormatThenWeightedAdd(t *testing.T) {
+	old := New("old", []string{"l0", "l1"})
+	old.LWFW = nil
+	old.LabelWordWeightSum = nil
+
+	var buf bytes.Buffer
+	if _, err := buf.Write(magicGob[:]); err != nil {
+		t.Fatalf("write magic failed: %v", err)
+	}
+	if err := gob.NewEncoder(&buf).Encode(old); err != nil {
+		t.Fatalf("gob encode failed: %v", err)
+	}
+
+	dd, err := LoadGob(&buf)
+	if err != nil {
+		t.Fatalf("LoadGob failed: %v", err)
+	}
+
+	conf.Weighting = WeightingTFIDF
+	defer func() { conf.Weighting = WeightingCount }()
+
+	dd.Add("new", []string{"bar"}, []int{1})
+
+	if dd.LWFW[1][dd.idxs["bar"]] <= 0 {
+		t.Errorf("LWFW should be populated after Add on a loaded old-format gob file, got %v", dd.LWFW[1][dd.idxs["bar"]])
+	}
+}
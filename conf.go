@@ -1,23 +1,37 @@
-package bayesbow
-
-// Config :
-type Config struct {
-	UseStopWords    bool
-	StopWords       []string
-	StopWordClasses []string
-}
-
-func makeDefaultConfig() Config {
-	return Config{}
-}
-
-// Init :
-func Init(c Config) (err error) {
-	conf = c
-
-	// [TODO] なにかデフォルトでやらないと困ることがあればここに入れる。
-
-	return
-}
-
-var conf Config
+package bayesbow
+
+// Weighting の値。PWL/PLD で単語の重みをどう数えるかを切り替える。
+const (
+	WeightingCount          = ""                // 出現回数をそのまま使う（従来どおり・デフォルト）
+	WeightingTF             = "tf"              // 文書内の出現回数(TF)を重みとして使う
+	WeightingTFIDF          = "tfidf"           // TF-IDF値を重みとして使う
+	WeightingSublinearTFIDF = "sublinear-tfidf" // log(1+TF)・IDF値を重みとして使う
+)
+
+// Config :
+type Config struct {
+	UseStopWords    bool
+	StopWords       []string
+	StopWordClasses []string
+	Weighting       string   // PWL/PLD の重み付けモード。WeightingXxx 定数のいずれか。空文字なら従来どおりの出現回数ベース
+	Alpha           float64  // Weighting が従来以外のときに使う加算スムージングの係数（0ならデフォルト値 1.0 を使う）
+	Analyzer        Analyzer // AddText/PredictText がテキストをトークン化するのに使う。nil なら WhitespaceAnalyzer を使う
+}
+
+func makeDefaultConfig() Config {
+	return Config{
+		Weighting: WeightingCount,
+		Alpha:     1.0,
+	}
+}
+
+// Init :
+func Init(c Config) (err error) {
+	conf = c
+
+	// [TODO] なにかデフォルトでやらないと困ることがあればここに入れる。
+
+	return
+}
+
+var conf Config
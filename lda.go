@@ -0,0 +1,229 @@
+package bayesbow
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// LDA : Bow が持つ語彙・文書の単語列を再利用して LDA (Latent Dirichlet Allocation) の
+// トピック推定を行う型。崩壊型ギブスサンプリング (collapsed Gibbs sampling) で学習する。
+type LDA struct {
+	Bow   *Bow
+	K     int     // トピック数
+	Alpha float64 // 文書-トピック分布のディリクレ事前分布パラメータ
+	Beta  float64 // トピック-単語分布のディリクレ事前分布パラメータ
+
+	nwz [][]int // 単語-トピックの出現数 (単語ID → トピックID → 出現数)
+	ndz [][]int // 文書-トピックの出現数 (文書ID → トピックID → 出現数)
+	nz  []int   // トピックごとの総出現数 (トピックID → 出現数)
+	z   [][]int // 文書中の各トークンのトピック割り当て (文書ID → 単語位置 → トピックID)
+
+	rnd *rand.Rand
+}
+
+// NewLDA : bow.Docs (文書ごとの単語IDの並び) をもとに LDA を作成し、トピック割り当てをランダムに初期化する
+func NewLDA(bow *Bow, k int, alpha, beta float64) (r *LDA) {
+	r = &LDA{
+		Bow:   bow,
+		K:     k,
+		Alpha: alpha,
+		Beta:  beta,
+		rnd:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	r.nwz = make([][]int, bow.WordCount)
+	for w := range r.nwz {
+		r.nwz[w] = make([]int, k)
+	}
+	r.ndz = make([][]int, len(bow.Docs))
+	r.nz = make([]int, k)
+	r.z = make([][]int, len(bow.Docs))
+
+	for d, seq := range bow.Docs {
+		r.ndz[d] = make([]int, k)
+		r.z[d] = make([]int, len(seq))
+		for i, w := range seq {
+			topic := r.rnd.Intn(k)
+			r.z[d][i] = topic
+			r.nwz[w][topic]++
+			r.ndz[d][topic]++
+			r.nz[topic]++
+		}
+	}
+	return
+}
+
+// RunGibbs : 崩壊型ギブスサンプリングを iters 回繰り返す
+func (l *LDA) RunGibbs(iters int) {
+	for sweep := 0; sweep < iters; sweep++ {
+		for d, seq := range l.Bow.Docs {
+			for i, w := range seq {
+				l.sampleToken(d, i, w, l.nwz, l.ndz[d], l.nz)
+			}
+		}
+	}
+}
+
+// sampleToken : 文書 d の位置 i (単語ID w) のトピックを現在のカウントから除いたうえで
+// カテゴリカル分布 p(z=k) ∝ (nwz[w][k]+beta)/(nz[k]+V*beta) * (ndz[d][k]+alpha) からサンプルし直す
+func (l *LDA) sampleToken(d, i, w int, nwz [][]int, ndz []int, nz []int) {
+	v := float64(len(nwz))
+
+	oldTopic := l.z[d][i]
+	nwz[w][oldTopic]--
+	ndz[oldTopic]--
+	nz[oldTopic]--
+
+	p := make([]float64, l.K)
+	sum := 0.0
+	for k := 0; k < l.K; k++ {
+		p[k] = (float64(nwz[w][k]) + l.Beta) / (float64(nz[k]) + v*l.Beta) * (float64(ndz[k]) + l.Alpha)
+		sum += p[k]
+	}
+
+	newTopic := l.K - 1
+	threshold := l.rnd.Float64() * sum
+	acc := 0.0
+	for k := 0; k < l.K; k++ {
+		acc += p[k]
+		if acc >= threshold {
+			newTopic = k
+			break
+		}
+	}
+
+	l.z[d][i] = newTopic
+	nwz[w][newTopic]++
+	ndz[newTopic]++
+	nz[newTopic]++
+}
+
+// TopicWords : トピック z の上位 n 単語を出現数の多い順に返す
+func (l *LDA) TopicWords(z, n int) (r []string) {
+	type wc struct {
+		wordID int
+		count  int
+	}
+	wcs := make([]wc, len(l.nwz))
+	for w := range l.nwz {
+		wcs[w] = wc{w, l.nwz[w][z]}
+	}
+	sort.Slice(wcs, func(i, j int) bool { return wcs[i].count > wcs[j].count })
+
+	if n > len(wcs) {
+		n = len(wcs)
+	}
+	// l.Bow.Words は Add/Predict では更新されないため、idxs から再構築した語彙を使う
+	words := l.Bow.wordsByID()
+	r = make([]string, n)
+	for i := 0; i < n; i++ {
+		r[i] = words[wcs[i].wordID]
+	}
+	return
+}
+
+// DocTopics : 文書 docID のトピック分布を返す
+func (l *LDA) DocTopics(docID int) (r []float64) {
+	r = make([]float64, l.K)
+	sum := 0.0
+	for k := 0; k < l.K; k++ {
+		r[k] = float64(l.ndz[docID][k]) + l.Alpha
+		sum += r[k]
+	}
+	for k := range r {
+		r[k] /= sum
+	}
+	return
+}
+
+// Predict : 未知の文書 words のトピック分布を、学習済みの nwz/nz を固定したまま推定する。
+// burnIn 回のギブスサンプリングを捨ててから samples 回分の分布を平均する。
+// words のうち語彙に存在しない単語 (OOV) は読み飛ばす。
+func (l *LDA) Predict(words []string, burnIn, samples int) (r []float64) {
+	seq := []int{}
+	for _, word := range words {
+		if idx, ok := l.Bow.idxs[word]; ok {
+			seq = append(seq, idx)
+		}
+	}
+
+	// 学習済みの nwz/nz はどちらも変更しないよう、予測に必要な単語の行だけをローカルにコピーする。
+	// こうしておけば Predict を呼んでも後続の Predict/RunGibbs/TopicWords の結果は変わらない。
+	// ただし乱数生成器 l.rnd は共有しており、RunGibbs は nwz/nz を書き換えるため、同じ *LDA への並行呼び出しは安全ではない。
+	localNwz := map[int][]int{}
+	for _, w := range seq {
+		if _, ok := localNwz[w]; !ok {
+			row := make([]int, l.K)
+			copy(row, l.nwz[w])
+			localNwz[w] = row
+		}
+	}
+	nz := make([]int, l.K)
+	copy(nz, l.nz)
+
+	ndz := make([]int, l.K)
+	z := make([]int, len(seq))
+	for i, w := range seq {
+		topic := l.rnd.Intn(l.K)
+		z[i] = topic
+		localNwz[w][topic]++
+		ndz[topic]++
+		nz[topic]++
+	}
+
+	sampleOne := func() {
+		for i, w := range seq {
+			row := localNwz[w]
+			v := float64(len(l.nwz))
+			oldTopic := z[i]
+			row[oldTopic]--
+			ndz[oldTopic]--
+			nz[oldTopic]--
+
+			p := make([]float64, l.K)
+			sum := 0.0
+			for k := 0; k < l.K; k++ {
+				p[k] = (float64(row[k]) + l.Beta) / (float64(nz[k]) + v*l.Beta) * (float64(ndz[k]) + l.Alpha)
+				sum += p[k]
+			}
+			newTopic := l.K - 1
+			threshold := l.rnd.Float64() * sum
+			acc := 0.0
+			for k := 0; k < l.K; k++ {
+				acc += p[k]
+				if acc >= threshold {
+					newTopic = k
+					break
+				}
+			}
+			z[i] = newTopic
+			row[newTopic]++
+			ndz[newTopic]++
+			nz[newTopic]++
+		}
+	}
+
+	for i := 0; i < burnIn; i++ {
+		sampleOne()
+	}
+
+	r = make([]float64, l.K)
+	for s := 0; s < samples; s++ {
+		sampleOne()
+		for k := 0; k < l.K; k++ {
+			r[k] += float64(ndz[k]) + l.Alpha
+		}
+	}
+
+	sum := 0.0
+	for _, v := range r {
+		sum += v
+	}
+	if sum > 0 {
+		for k := range r {
+			r[k] /= sum
+		}
+	}
+	return
+}
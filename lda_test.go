@@ -0,0 +1,62 @@
+package bayesbow
+
+import "testing"
+
+func testLDABow() *Bow {
+	b := New("lda", []string{"l0"})
+	b.Add("1", []string{"foo", "bar", "foo", "baz"}, []int{0})
+	b.Add("2", []string{"bar", "baz", "bar"}, []int{0})
+	b.Add("3", []string{"foo", "foo", "baz"}, []int{0})
+	return b
+}
+
+// LDA.Predict は学習済みの nwz/nz を変更してはならない（後続の呼び出しの結果に影響を与えないため）。
+func TestLDAPredictDoesNotMutateTrainedState(t *testing.T) {
+	b := testLDABow()
+	lda := NewLDA(b, 2, 0.1, 0.1)
+	lda.RunGibbs(5)
+
+	wantNwz := make([][]int, len(lda.nwz))
+	for i, row := range lda.nwz {
+		wantNwz[i] = append([]int(nil), row...)
+	}
+	wantNz := append([]int(nil), lda.nz...)
+
+	lda.Predict([]string{"foo", "bar", "qux"}, 5, 5)
+
+	for w, row := range lda.nwz {
+		for k, count := range row {
+			if count != wantNwz[w][k] {
+				t.Errorf("nwz[%d][%d] changed by Predict: got %d, want %d", w, k, count, wantNwz[w][k])
+			}
+		}
+	}
+	for k, count := range lda.nz {
+		if count != wantNz[k] {
+			t.Errorf("nz[%d] changed by Predict: got %d, want %d", k, count, wantNz[k])
+		}
+	}
+}
+
+func TestLDATopicWordsAndDocTopics(t *testing.T) {
+	b := testLDABow()
+	lda := NewLDA(b, 2, 0.1, 0.1)
+	lda.RunGibbs(10)
+
+	words := lda.TopicWords(0, 3)
+	if len(words) != 3 {
+		t.Fatalf("TopicWords returned %d words, want 3", len(words))
+	}
+
+	dist := lda.DocTopics(0)
+	if len(dist) != 2 {
+		t.Fatalf("DocTopics returned %d entries, want 2", len(dist))
+	}
+	sum := 0.0
+	for _, p := range dist {
+		sum += p
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("DocTopics should sum to 1, got %v", sum)
+	}
+}
@@ -0,0 +1,248 @@
+package bayesbow
+
+import "strings"
+
+// PorterStem : 英単語を Porter の語幹抽出アルゴリズム (Porter, 1980) で語幹に変換する。
+// 語幹抽出の前に小文字化するため、戻り値は常に小文字になる。
+func PorterStem(word string) string {
+	w := []rune(strings.ToLower(word))
+	if len(w) <= 2 {
+		return string(w)
+	}
+
+	w = porterStep1a(w)
+	w = porterStep1b(w)
+	w = porterStep1c(w)
+	w = porterStep2(w)
+	w = porterStep3(w)
+	w = porterStep4(w)
+	w = porterStep5a(w)
+	w = porterStep5b(w)
+
+	return string(w)
+}
+
+// isConsonant : w[i] が子音かどうかを判定する。Y は語頭にあるか直前が母音のときだけ子音として扱う。
+func isConsonant(w []rune, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		if i == 0 {
+			return true
+		}
+		return !isConsonant(w, i-1)
+	}
+	return true
+}
+
+// measure : [C](VC){m}[V] における m (子音・母音の連続パターンの繰り返し回数) を返す。
+func measure(w []rune) int {
+	m := 0
+	i := 0
+	n := len(w)
+	for i < n && isConsonant(w, i) {
+		i++
+	}
+	for i < n {
+		for i < n && !isConsonant(w, i) {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		for i < n && isConsonant(w, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+// containsVowel : 語幹候補の中に母音が含まれているか (*v* 条件)
+func containsVowel(w []rune) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsDoubleConsonant : 末尾が子音の重複かどうか (*d 条件)
+func endsDoubleConsonant(w []rune) bool {
+	n := len(w)
+	if n < 2 {
+		return false
+	}
+	return w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsCVC : 語幹の末尾が 子音・母音・子音(ただし最後の子音は w,x,y以外) のパターンか (*o 条件)
+func endsCVC(w []rune) bool {
+	n := len(w)
+	if n < 3 {
+		return false
+	}
+	if !isConsonant(w, n-3) || isConsonant(w, n-2) || !isConsonant(w, n-1) {
+		return false
+	}
+	switch w[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+func hasSuffix(w []rune, suf string) bool {
+	return strings.HasSuffix(string(w), suf)
+}
+
+func trimSuffix(w []rune, n int) []rune {
+	return w[:len(w)-n]
+}
+
+func replaceSuffix(w []rune, old, new string, cond func([]rune) bool) ([]rune, bool) {
+	if !hasSuffix(w, old) {
+		return w, false
+	}
+	stem := trimSuffix(w, len(old))
+	if cond != nil && !cond(stem) {
+		return w, false
+	}
+	return append(stem, []rune(new)...), true
+}
+
+func porterStep1a(w []rune) []rune {
+	switch {
+	case hasSuffix(w, "sses"):
+		return trimSuffix(w, 2)
+	case hasSuffix(w, "ies"):
+		return trimSuffix(w, 2)
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return trimSuffix(w, 1)
+	}
+	return w
+}
+
+func porterStep1b(w []rune) []rune {
+	// "eed"(系)と "ed"/"ing"(系)は排他な分岐であり、"eed" に一致した場合は
+	// m>0 の条件を満たさなくてもそこで確定する（"ed" 側へは絶対に落ちない）。
+	// "eed" で終わる語は必ず "ed" でも終わるため、ここを独立した if にすると
+	// 二重に接尾辞が剥がされてしまう (例: "feed" → "fe" になってしまう)。
+	if hasSuffix(w, "eed") {
+		if r, ok := replaceSuffix(w, "eed", "ee", func(stem []rune) bool { return measure(stem) > 0 }); ok {
+			return r
+		}
+		return w
+	}
+
+	var didEdOrIng bool
+	if r, ok := replaceSuffix(w, "ed", "", func(stem []rune) bool { return containsVowel(stem) }); ok {
+		w, didEdOrIng = r, true
+	} else if r, ok := replaceSuffix(w, "ing", "", func(stem []rune) bool { return containsVowel(stem) }); ok {
+		w, didEdOrIng = r, true
+	}
+	if !didEdOrIng {
+		return w
+	}
+
+	switch {
+	case hasSuffix(w, "at"), hasSuffix(w, "bl"), hasSuffix(w, "iz"):
+		return append(w, 'e')
+	case endsDoubleConsonant(w) && !hasSuffix(w, "l") && !hasSuffix(w, "s") && !hasSuffix(w, "z"):
+		return trimSuffix(w, 1)
+	case measure(w) == 1 && endsCVC(w):
+		return append(w, 'e')
+	}
+	return w
+}
+
+func porterStep1c(w []rune) []rune {
+	if r, ok := replaceSuffix(w, "y", "i", func(stem []rune) bool { return containsVowel(stem) }); ok {
+		return r
+	}
+	return w
+}
+
+// step2Rules : (m>0) という条件で適用する接尾辞の置換表
+var step2Rules = []struct{ old, new string }{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+}
+
+func porterStep2(w []rune) []rune {
+	for _, rule := range step2Rules {
+		if r, ok := replaceSuffix(w, rule.old, rule.new, func(stem []rune) bool { return measure(stem) > 0 }); ok {
+			return r
+		}
+	}
+	return w
+}
+
+var step3Rules = []struct{ old, new string }{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+func porterStep3(w []rune) []rune {
+	for _, rule := range step3Rules {
+		if r, ok := replaceSuffix(w, rule.old, rule.new, func(stem []rune) bool { return measure(stem) > 0 }); ok {
+			return r
+		}
+	}
+	return w
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement",
+	"ment", "ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func porterStep4(w []rune) []rune {
+	for _, suf := range step4Suffixes {
+		if !hasSuffix(w, suf) {
+			continue
+		}
+		stem := trimSuffix(w, len(suf))
+		if suf == "ion" {
+			if len(stem) == 0 {
+				continue
+			}
+			last := stem[len(stem)-1]
+			if last != 's' && last != 't' {
+				continue
+			}
+		}
+		if measure(stem) > 1 {
+			return stem
+		}
+		return w
+	}
+	return w
+}
+
+func porterStep5a(w []rune) []rune {
+	if !hasSuffix(w, "e") {
+		return w
+	}
+	stem := trimSuffix(w, 1)
+	m := measure(stem)
+	if m > 1 || (m == 1 && !endsCVC(stem)) {
+		return stem
+	}
+	return w
+}
+
+func porterStep5b(w []rune) []rune {
+	if measure(w) > 1 && endsDoubleConsonant(w) && hasSuffix(w, "l") {
+		return trimSuffix(w, 1)
+	}
+	return w
+}
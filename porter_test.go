@@ -0,0 +1,35 @@
+package bayesbow
+
+import "testing"
+
+func TestPorterStem(t *testing.T) {
+	cases := []struct {
+		word, want string
+	}{
+		// Porter の仕様書に挙がっている "eed"/"ed"/"ing" の例。
+		// "eed" は m>0 を満たさない限りそのまま残り、"ed"/"ing" 側には絶対に落ちない。
+		{"feed", "feed"},
+		{"need", "need"},
+		{"speed", "speed"},
+		{"freed", "freed"},
+		{"agreed", "agre"},
+
+		{"running", "run"},
+		{"happiness", "happi"},
+		{"relational", "relat"},
+		{"flies", "fli"},
+		{"caresses", "caress"},
+		{"plastered", "plaster"},
+		{"bled", "bled"},
+		{"motoring", "motor"},
+		{"sizing", "size"},
+		{"hopping", "hop"},
+		{"hopefulness", "hope"},
+	}
+
+	for _, c := range cases {
+		if got := PorterStem(c.word); got != c.want {
+			t.Errorf("PorterStem(%q) = %q, want %q", c.word, got, c.want)
+		}
+	}
+}
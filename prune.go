@@ -0,0 +1,171 @@
+package bayesbow
+
+import "sort"
+
+// PruneOptions : Bow.Prune に与える語彙の刈り込み条件。0 (または 0.0) を指定した条件は無効。
+// 複数指定した場合はすべての条件を満たす単語だけが残る (MaxVocabSize は残った単語への上限として最後に適用される)。
+type PruneOptions struct {
+	MinDocFreq     int     // 出現する文書数がこれ未満の単語を削除する
+	MaxDocFraction float64 // 出現する文書数の割合がこれを超える単語を削除する（データドリブンなストップワード除去に使う）
+	TopKPerDoc     int     // いずれかの文書内で出現回数が上位 K 件に入らない単語を削除する
+	MaxVocabSize   int     // 他の条件を適用した後、出現文書数の多い順に上位何語を残すか
+}
+
+// Prune : opts の条件に基づき語彙を刈り込み、Words/idxs/WordDocCount/LWF/LabelWordCount/
+// LWFW/LabelWordWeightSum/Docs を圧縮済みの単語IDで再構築する
+func (dd *Bow) Prune(opts PruneOptions) {
+	oldWordCount := dd.WordCount
+	oldWords := dd.wordsByID()
+
+	keep := make([]bool, oldWordCount)
+	for i := range keep {
+		keep[i] = true
+	}
+
+	if opts.MinDocFreq > 0 {
+		for id := 0; id < oldWordCount; id++ {
+			if dd.WordDocCount[id] < opts.MinDocFreq {
+				keep[id] = false
+			}
+		}
+	}
+
+	if opts.MaxDocFraction > 0 {
+		limit := opts.MaxDocFraction * float64(dd.DocCount)
+		for id := 0; id < oldWordCount; id++ {
+			if float64(dd.WordDocCount[id]) > limit {
+				keep[id] = false
+			}
+		}
+	}
+
+	if opts.TopKPerDoc > 0 {
+		inTopK := dd.topKPerDocWords(opts.TopKPerDoc)
+		for id := 0; id < oldWordCount; id++ {
+			if !inTopK[id] {
+				keep[id] = false
+			}
+		}
+	}
+
+	if opts.MaxVocabSize > 0 {
+		survivors := make([]int, 0, oldWordCount)
+		for id := 0; id < oldWordCount; id++ {
+			if keep[id] {
+				survivors = append(survivors, id)
+			}
+		}
+		if len(survivors) > opts.MaxVocabSize {
+			sort.Slice(survivors, func(i, j int) bool {
+				return dd.WordDocCount[survivors[i]] > dd.WordDocCount[survivors[j]]
+			})
+			for _, id := range survivors[opts.MaxVocabSize:] {
+				keep[id] = false
+			}
+		}
+	}
+
+	// 旧単語ID → 新単語ID のマッピング (削除された単語は -1)
+	mapping := make([]int, oldWordCount)
+	newWordCount := 0
+	for id := 0; id < oldWordCount; id++ {
+		if keep[id] {
+			mapping[id] = newWordCount
+			newWordCount++
+		} else {
+			mapping[id] = -1
+		}
+	}
+
+	newWords := make([]string, newWordCount)
+	newIdxs := map[string]int{}
+	newWordDocCount := map[int]int{}
+	for oldID, newID := range mapping {
+		if newID < 0 {
+			continue
+		}
+		newWords[newID] = oldWords[oldID]
+		newIdxs[oldWords[oldID]] = newID
+		newWordDocCount[newID] = dd.WordDocCount[oldID]
+	}
+
+	newLWF := map[int]map[int]int{}
+	newLabelWordCount := map[int]int{}
+	for labelID, wordMap := range dd.LWF {
+		m := map[int]int{}
+		sum := 0
+		for oldID, count := range wordMap {
+			if newID := mapping[oldID]; newID >= 0 {
+				m[newID] = count
+				sum += count
+			}
+		}
+		newLWF[labelID] = m
+		newLabelWordCount[labelID] = sum
+	}
+
+	newLWFW := map[int]map[int]float64{}
+	newLabelWordWeightSum := map[int]float64{}
+	for labelID, wordMap := range dd.LWFW {
+		m := map[int]float64{}
+		sum := 0.0
+		for oldID, w := range wordMap {
+			if newID := mapping[oldID]; newID >= 0 {
+				m[newID] = w
+				sum += w
+			}
+		}
+		newLWFW[labelID] = m
+		newLabelWordWeightSum[labelID] = sum
+	}
+
+	newDocs := make([][]int, len(dd.Docs))
+	for d, seq := range dd.Docs {
+		newSeq := make([]int, 0, len(seq))
+		for _, oldID := range seq {
+			if newID := mapping[oldID]; newID >= 0 {
+				newSeq = append(newSeq, newID)
+			}
+		}
+		newDocs[d] = newSeq
+	}
+
+	dd.WordCount = newWordCount
+	dd.Words = newWords
+	dd.idxs = newIdxs
+	dd.WordDocCount = newWordDocCount
+	dd.LWF = newLWF
+	dd.LabelWordCount = newLabelWordCount
+	dd.LWFW = newLWFW
+	dd.LabelWordWeightSum = newLabelWordWeightSum
+	dd.Docs = newDocs
+}
+
+// topKPerDocWords : いずれかの文書内で出現回数が上位 k 件に入る単語IDの集合を返す
+func (dd *Bow) topKPerDocWords(k int) map[int]bool {
+	type wordFreq struct {
+		id, count int
+	}
+
+	r := map[int]bool{}
+	for _, seq := range dd.Docs {
+		freq := map[int]int{}
+		for _, id := range seq {
+			freq[id] = freq[id] + 1
+		}
+		list := make([]wordFreq, 0, len(freq))
+		for id, count := range freq {
+			list = append(list, wordFreq{id, count})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].count > list[j].count })
+
+		top := k
+		if top > len(list) {
+			top = len(list)
+		}
+		for i := 0; i < top; i++ {
+			r[list[i].id] = true
+		}
+	}
+	return r
+}
@@ -0,0 +1,107 @@
+package bayesbow
+
+import "testing"
+
+func TestPruneMinDocFreq(t *testing.T) {
+	b := New("prune", []string{"l0"})
+	b.Add("1", []string{"common", "rare"}, []int{0})
+	b.Add("2", []string{"common"}, []int{0})
+	b.Add("3", []string{"common"}, []int{0})
+
+	b.Prune(PruneOptions{MinDocFreq: 2})
+
+	if _, ok := b.idxs["common"]; !ok {
+		t.Errorf("common (doc freq 3) should survive MinDocFreq=2")
+	}
+	if _, ok := b.idxs["rare"]; ok {
+		t.Errorf("rare (doc freq 1) should be removed by MinDocFreq=2")
+	}
+}
+
+func TestPruneMaxDocFraction(t *testing.T) {
+	b := New("prune", []string{"l0"})
+	b.Add("1", []string{"everywhere", "sometimes"}, []int{0})
+	b.Add("2", []string{"everywhere"}, []int{0})
+	b.Add("3", []string{"everywhere"}, []int{0})
+
+	// everywhere は3文書中3文書、sometimes は3文書中1文書に出現する
+	b.Prune(PruneOptions{MaxDocFraction: 0.5})
+
+	if _, ok := b.idxs["everywhere"]; ok {
+		t.Errorf("everywhere (doc fraction 1.0) should be removed by MaxDocFraction=0.5")
+	}
+	if _, ok := b.idxs["sometimes"]; !ok {
+		t.Errorf("sometimes (doc fraction 1/3) should survive MaxDocFraction=0.5")
+	}
+}
+
+// TopKPerDoc は MaxVocabSize より先に適用されるため、文書内で上位に入らない単語は
+// 全体としての出現文書数がどれだけ多くても MaxVocabSize の判定に回ることすらなく除外される。
+func TestPruneTopKPerDocAppliesBeforeMaxVocabSize(t *testing.T) {
+	b := New("prune", []string{"l0"})
+	b.Add("1", []string{"a", "a", "x"}, []int{0})
+	b.Add("2", []string{"b", "b", "x"}, []int{0})
+	b.Add("3", []string{"c", "c", "x"}, []int{0})
+	b.Add("4", []string{"a", "a"}, []int{0})
+	b.Add("5", []string{"b", "b"}, []int{0})
+
+	// x は5文書中3文書に出現し、a/b (各2文書) より出現文書数は多いが、
+	// どの文書内でも上位1語 (a, b, c) には入らないため TopKPerDoc=1 でまず除外される。
+	if got := b.WordDocCount[b.idxs["x"]]; got != 3 {
+		t.Fatalf("sanity check failed: x doc freq = %d, want 3", got)
+	}
+
+	b.Prune(PruneOptions{TopKPerDoc: 1, MaxVocabSize: 2})
+
+	if _, ok := b.idxs["x"]; ok {
+		t.Errorf("x should have been excluded by TopKPerDoc before MaxVocabSize ever considered it")
+	}
+	if _, ok := b.idxs["c"]; ok {
+		t.Errorf("c (doc freq 1) should be trimmed by MaxVocabSize=2 among the TopKPerDoc survivors {a,b,c}")
+	}
+	for _, word := range []string{"a", "b"} {
+		if _, ok := b.idxs[word]; !ok {
+			t.Errorf("%s (doc freq 2) should survive as one of the top 2 TopKPerDoc survivors", word)
+		}
+	}
+	if b.WordCount != 2 {
+		t.Errorf("WordCount = %d, want 2", b.WordCount)
+	}
+}
+
+// Prune 後は Words/idxs/WordDocCount/LWF/LabelWordCount/LWFW/LabelWordWeightSum/Docs が
+// すべて圧縮済みの単語IDで一貫して再構築されていること。
+func TestPruneCompactsDerivedStructures(t *testing.T) {
+	conf.Weighting = WeightingTFIDF
+	defer func() { conf.Weighting = WeightingCount }()
+
+	b := New("prune", []string{"l0", "l1"})
+	b.Add("1", []string{"keep", "drop"}, []int{0})
+	b.Add("2", []string{"keep"}, []int{1})
+
+	b.Prune(PruneOptions{MinDocFreq: 2})
+
+	keepID, ok := b.idxs["keep"]
+	if !ok {
+		t.Fatalf("keep should survive")
+	}
+	if keepID != 0 {
+		t.Errorf("remaining word should be compacted to id 0, got %d", keepID)
+	}
+	if b.Words[keepID] != "keep" {
+		t.Errorf("Words[%d] = %q, want %q", keepID, b.Words[keepID], "keep")
+	}
+	if b.LWF[0][keepID] != 1 || b.LWF[1][keepID] != 1 {
+		t.Errorf("LWF not remapped correctly: %v", b.LWF)
+	}
+	if b.LWFW[0][keepID] <= 0 || b.LWFW[1][keepID] <= 0 {
+		t.Errorf("LWFW not remapped correctly: %v", b.LWFW)
+	}
+	for _, seq := range b.Docs {
+		for _, id := range seq {
+			if id != keepID {
+				t.Errorf("Docs should only reference compacted ids, found %d", id)
+			}
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package bayesbow
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+)
+
+// コンパクトなバイナリ形式を見分けるためのマジックバイト
+var (
+	magicGob    = [4]byte{'B', 'B', 'G', '1'}
+	magicStream = [4]byte{'B', 'B', 'S', '1'}
+)
+
+// SaveGob : Bow を encoding/gob を使ったコンパクトなバイナリ形式で書き出す。
+// JSON の Save に比べてファイルサイズが小さく、書き出しも速い。
+func (dd *Bow) SaveGob(w io.Writer) (err error) {
+	dd.updateWords()
+	if _, err = w.Write(magicGob[:]); err != nil {
+		return
+	}
+	err = gob.NewEncoder(w).Encode(dd)
+	return
+}
+
+// LoadGob : SaveGob で書き出したバイナリ形式を読み込む
+func LoadGob(r io.Reader) (dd *Bow, err error) {
+	var magic [4]byte
+	if _, err = io.ReadFull(r, magic[:]); err != nil {
+		return
+	}
+	if magic != magicGob {
+		err = fmt.Errorf("bayesbow: LoadGob: マジックバイトが一致しません")
+		return
+	}
+
+	var d Bow
+	if err = gob.NewDecoder(r).Decode(&d); err != nil {
+		return
+	}
+
+	d.idxs = map[string]int{}
+	for idx, word := range d.Words {
+		d.idxs[word] = idx
+	}
+	dd = &d
+	dd.ensureWeightedMaps()
+	return
+}
+
+// SaveStream : ヘッダ（文書数・ラベル名・語彙）に続けて、ラベルごとに単語出現数の疎なレコード
+// (labelID, [](wordID, count)) を長さ付きで書き出す。LWF は単語数×ラベル数で巨大になりがちだが、
+// この形式なら Load 時に中間マップをまるごとメモリに展開せずストリームで読み進められ、
+// レコードはラベルごとに独立しているため並行デコードもできる。
+// LWFW/LabelWordWeightSum（TF-IDF重み付け用）と Docs（LDA用の語順）はこの形式には含めない。
+func (dd *Bow) SaveStream(w io.Writer) (err error) {
+	dd.updateWords()
+	bw := bufio.NewWriter(w)
+
+	if _, err = bw.Write(magicStream[:]); err != nil {
+		return
+	}
+	if err = writeString(bw, dd.Note); err != nil {
+		return
+	}
+	if err = binary.Write(bw, binary.LittleEndian, int64(dd.DocCount)); err != nil {
+		return
+	}
+	if err = binary.Write(bw, binary.LittleEndian, int32(dd.LabelCount)); err != nil {
+		return
+	}
+	for labelID := 0; labelID < dd.LabelCount; labelID++ {
+		if err = writeString(bw, dd.LabelNames[labelID]); err != nil {
+			return
+		}
+		if err = binary.Write(bw, binary.LittleEndian, int64(dd.LabelDocCount[labelID])); err != nil {
+			return
+		}
+		if err = binary.Write(bw, binary.LittleEndian, dd.PL[labelID]); err != nil {
+			return
+		}
+	}
+
+	if err = binary.Write(bw, binary.LittleEndian, int32(dd.WordCount)); err != nil {
+		return
+	}
+	for wordID := 0; wordID < dd.WordCount; wordID++ {
+		if err = writeString(bw, dd.Words[wordID]); err != nil {
+			return
+		}
+		if err = binary.Write(bw, binary.LittleEndian, int64(dd.WordDocCount[wordID])); err != nil {
+			return
+		}
+	}
+
+	// ラベルごとの単語出現数を疎なレコードとして書き出す
+	for labelID := 0; labelID < dd.LabelCount; labelID++ {
+		wordMap := dd.LWF[labelID]
+		if err = binary.Write(bw, binary.LittleEndian, int32(labelID)); err != nil {
+			return
+		}
+		if err = binary.Write(bw, binary.LittleEndian, int32(len(wordMap))); err != nil {
+			return
+		}
+		for wordID, count := range wordMap {
+			if err = binary.Write(bw, binary.LittleEndian, int32(wordID)); err != nil {
+				return
+			}
+			if err = binary.Write(bw, binary.LittleEndian, int64(count)); err != nil {
+				return
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// LoadStream : SaveStream で書き出したバイナリ形式を読み込む
+func LoadStream(r io.Reader) (dd *Bow, err error) {
+	br := bufio.NewReader(r)
+
+	var magic [4]byte
+	if _, err = io.ReadFull(br, magic[:]); err != nil {
+		return
+	}
+	if magic != magicStream {
+		err = fmt.Errorf("bayesbow: LoadStream: マジックバイトが一致しません")
+		return
+	}
+
+	var d Bow
+	if d.Note, err = readString(br); err != nil {
+		return
+	}
+
+	var docCount int64
+	if err = binary.Read(br, binary.LittleEndian, &docCount); err != nil {
+		return
+	}
+	d.DocCount = int(docCount)
+
+	var labelCount int32
+	if err = binary.Read(br, binary.LittleEndian, &labelCount); err != nil {
+		return
+	}
+	d.LabelCount = int(labelCount)
+	d.LabelNames = make([]string, labelCount)
+	d.LabelDocCount = make([]int, labelCount)
+	d.PL = make([]float64, labelCount)
+	for labelID := 0; labelID < int(labelCount); labelID++ {
+		if d.LabelNames[labelID], err = readString(br); err != nil {
+			return
+		}
+		var labelDocCount int64
+		if err = binary.Read(br, binary.LittleEndian, &labelDocCount); err != nil {
+			return
+		}
+		d.LabelDocCount[labelID] = int(labelDocCount)
+		if err = binary.Read(br, binary.LittleEndian, &d.PL[labelID]); err != nil {
+			return
+		}
+	}
+
+	var wordCount int32
+	if err = binary.Read(br, binary.LittleEndian, &wordCount); err != nil {
+		return
+	}
+	d.WordCount = int(wordCount)
+	d.Words = make([]string, wordCount)
+	d.WordDocCount = map[int]int{}
+	for wordID := 0; wordID < int(wordCount); wordID++ {
+		if d.Words[wordID], err = readString(br); err != nil {
+			return
+		}
+		var wordDocCount int64
+		if err = binary.Read(br, binary.LittleEndian, &wordDocCount); err != nil {
+			return
+		}
+		d.WordDocCount[wordID] = int(wordDocCount)
+	}
+
+	d.LWF = map[int]map[int]int{}
+	d.LabelWordCount = map[int]int{}
+	for i := 0; i < int(labelCount); i++ {
+		var labelID, numEntries int32
+		if err = binary.Read(br, binary.LittleEndian, &labelID); err != nil {
+			return
+		}
+		if err = binary.Read(br, binary.LittleEndian, &numEntries); err != nil {
+			return
+		}
+		wordMap := make(map[int]int, numEntries)
+		sum := 0
+		for e := int32(0); e < numEntries; e++ {
+			var wordID int32
+			var count int64
+			if err = binary.Read(br, binary.LittleEndian, &wordID); err != nil {
+				return
+			}
+			if err = binary.Read(br, binary.LittleEndian, &count); err != nil {
+				return
+			}
+			wordMap[int(wordID)] = int(count)
+			sum += int(count)
+		}
+		d.LWF[int(labelID)] = wordMap
+		d.LabelWordCount[int(labelID)] = sum
+	}
+
+	// LWFW/LabelWordWeightSum/Docs はこの形式には含めていないため空のまま
+	d.LWFW = map[int]map[int]float64{}
+	d.LabelWordWeightSum = map[int]float64{}
+
+	d.idxs = map[string]int{}
+	for idx, word := range d.Words {
+		d.idxs[word] = idx
+	}
+	dd = &d
+	dd.ensureWeightedMaps()
+	return
+}
+
+// LoadAuto : 先頭のマジックバイトを見て SaveGob / SaveStream / Save(JSON) のいずれの形式かを自動判別して読み込む
+func LoadAuto(path string) (dd *Bow, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var magic [4]byte
+	n, err := io.ReadFull(f, magic[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return
+	}
+	err = nil
+
+	rest := io.MultiReader(bytes.NewReader(magic[:n]), f)
+
+	switch {
+	case n == 4 && magic == magicGob:
+		dd, err = LoadGob(rest)
+	case n == 4 && magic == magicStream:
+		dd, err = LoadStream(rest)
+	default:
+		dd, err = Load(path)
+	}
+	return
+}
+
+func writeString(w io.Writer, s string) (err error) {
+	b := []byte(s)
+	if err = binary.Write(w, binary.LittleEndian, int32(len(b))); err != nil {
+		return
+	}
+	_, err = w.Write(b)
+	return
+}
+
+func readString(r io.Reader) (s string, err error) {
+	var n int32
+	if err = binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return
+	}
+	b := make([]byte, n)
+	if _, err = io.ReadFull(r, b); err != nil {
+		return
+	}
+	s = string(b)
+	return
+}